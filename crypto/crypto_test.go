@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	cek, err := generateCEK()
+	if err != nil {
+		t.Fatalf("generateCEK: %v", err)
+	}
+	iv, err := generateIV()
+	if err != nil {
+		t.Fatalf("generateIV: %v", err)
+	}
+
+	plaintext := []byte("a secret payload")
+	ciphertext, err := seal(cek, iv, plaintext)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	got, err := open(cek, iv, ciphertext)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("open returned %q, want %q", got, plaintext)
+	}
+}
+
+func TestOpenDetectsTamperedCiphertext(t *testing.T) {
+	cek, _ := generateCEK()
+	iv, _ := generateIV()
+
+	ciphertext, err := seal(cek, iv, []byte("a secret payload"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[0] ^= 0xFF
+
+	if _, err := open(cek, iv, tampered); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("open(tampered) = %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,54 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// wrapCEK asks KMS to encrypt cek under kmsKeyID, returning the wrapped key
+// and the material description stored alongside it.
+func wrapCEK(ctx context.Context, client kmsiface.KMSAPI, kmsKeyID string, cek []byte) (wrapped []byte, matDesc string, err error) {
+	desc := map[string]string{"kms_cmk_id": kmsKeyID}
+	matDescBytes, err := json.Marshal(desc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(kmsKeyID),
+		Plaintext:         cek,
+		EncryptionContext: map[string]*string{"kms_cmk_id": aws.String(kmsKeyID)},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("crypto: kms encrypt: %w", err)
+	}
+
+	return result.CiphertextBlob, string(matDescBytes), nil
+}
+
+// unwrapCEK asks KMS to decrypt a wrapped CEK using the key identified by
+// matDesc's kms_cmk_id, so decryption works even if kmsKeyID has rotated
+// since the object was written.
+func unwrapCEK(ctx context.Context, client kmsiface.KMSAPI, wrapped []byte, matDesc string) ([]byte, error) {
+	var desc map[string]string
+	if err := json.Unmarshal([]byte(matDesc), &desc); err != nil {
+		return nil, fmt.Errorf("crypto: parse matdesc: %w", err)
+	}
+	kmsKeyID := desc["kms_cmk_id"]
+
+	result, err := client.DecryptWithContext(ctx, &kms.DecryptInput{
+		CiphertextBlob:    wrapped,
+		KeyId:             aws.String(kmsKeyID),
+		EncryptionContext: map[string]*string{"kms_cmk_id": aws.String(kmsKeyID)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("crypto: kms decrypt: %w", err)
+	}
+
+	return result.Plaintext, nil
+}
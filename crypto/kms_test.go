@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// fakeKMS implements kmsiface.KMSAPI's Encrypt/Decrypt by XOR-ing the
+// plaintext with its key ID, which is reversible and good enough to
+// exercise wrapCEK/unwrapCEK without a real KMS key.
+type fakeKMS struct {
+	kmsiface.KMSAPI
+}
+
+func (fakeKMS) EncryptWithContext(ctx context.Context, input *kms.EncryptInput, _ ...request.Option) (*kms.EncryptOutput, error) {
+	return &kms.EncryptOutput{
+		CiphertextBlob: xorWithKeyID(input.Plaintext, *input.KeyId),
+		KeyId:          input.KeyId,
+	}, nil
+}
+
+func (fakeKMS) DecryptWithContext(ctx context.Context, input *kms.DecryptInput, _ ...request.Option) (*kms.DecryptOutput, error) {
+	return &kms.DecryptOutput{
+		Plaintext: xorWithKeyID(input.CiphertextBlob, *input.KeyId),
+		KeyId:     input.KeyId,
+	}, nil
+}
+
+func xorWithKeyID(data []byte, keyID string) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ keyID[i%len(keyID)]
+	}
+	return out
+}
+
+func TestWrapUnwrapCEKRoundTrip(t *testing.T) {
+	cek, err := generateCEK()
+	if err != nil {
+		t.Fatalf("generateCEK: %v", err)
+	}
+
+	wrapped, matDesc, err := wrapCEK(context.Background(), fakeKMS{}, "test-key-id", cek)
+	if err != nil {
+		t.Fatalf("wrapCEK: %v", err)
+	}
+
+	unwrapped, err := unwrapCEK(context.Background(), fakeKMS{}, wrapped, matDesc)
+	if err != nil {
+		t.Fatalf("unwrapCEK: %v", err)
+	}
+
+	if string(unwrapped) != string(cek) {
+		t.Fatalf("unwrapCEK returned %x, want %x", unwrapped, cek)
+	}
+}
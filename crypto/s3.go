@@ -0,0 +1,170 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	shared "github.com/iamtito/gopkgs"
+)
+
+// EncryptingS3 implements shared.S3Interface with client-side envelope
+// encryption: each object is encrypted with a one-time AES-GCM key, which is
+// itself wrapped by KMS and stored as object metadata.
+type EncryptingS3 struct {
+	s3  s3iface.S3API
+	kms kmsiface.KMSAPI
+
+	kmsKeyID string
+}
+
+// NewEncryptingS3 returns a shared.S3Interface that transparently encrypts
+// objects on upload and decrypts (with authentication) on download, using
+// kmsKeyID to wrap each object's content encryption key.
+func NewEncryptingS3(kmsKeyID string) shared.S3Interface {
+	sess := session.Must(session.NewSession(aws.NewConfig().WithRegion("us-east-1")))
+
+	return &EncryptingS3{
+		s3:       s3.New(sess),
+		kms:      kms.New(sess),
+		kmsKeyID: kmsKeyID,
+	}
+}
+
+//UploadFileToS3 encrypts the file at path and uploads it to bucketName.
+func (e *EncryptingS3) UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error {
+	plaintext, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	return e.upload(ctx, plaintext, bucketName, destinationName, contentType)
+}
+
+func (e *EncryptingS3) upload(ctx context.Context, plaintext []byte, bucket string, key string, contentType string) error {
+	cek, err := generateCEK()
+	if err != nil {
+		return err
+	}
+	iv, err := generateIV()
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := seal(cek, iv, plaintext)
+	if err != nil {
+		return err
+	}
+
+	wrappedCEK, matDesc, err := wrapCEK(ctx, e.kms, e.kmsKeyID, cek)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(ciphertext),
+		ContentType: aws.String(contentType),
+		Metadata: map[string]*string{
+			metadataKeyCEK:     aws.String(base64.StdEncoding.EncodeToString(wrappedCEK)),
+			metadataKeyIV:      aws.String(base64.StdEncoding.EncodeToString(iv)),
+			metadataKeyCEKAlg:  aws.String(cekAlgorithm),
+			metadataKeyWrap:    aws.String(wrapAlgorithm),
+			metadataKeyMatDesc: aws.String(matDesc),
+		},
+	}
+
+	_, err = e.s3.PutObjectWithContext(ctx, input)
+	return err
+}
+
+//DownloadFileFromS3 downloads an object uploaded by UploadFileToS3 and
+//returns its decrypted body. It returns ErrAuthFailed if the object's
+//authentication tag does not verify.
+func (e *EncryptingS3) DownloadFileFromS3(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	output, err := e.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	ciphertext, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := envelopeFromMetadata(normalizeMetadataKeys(output.Metadata))
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := unwrapCEK(ctx, e.kms, env.WrappedCEK, env.MatDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(cek, env.IV, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+// normalizeMetadataKeys lower-cases every key in an S3 GetObject response's
+// Metadata map. aws-sdk-go-v1 keys that map by the substring of the raw,
+// net/http-canonicalized header it received (e.g. "X-Amz-Key-V2"), not the
+// literal string PutObject was given (e.g. "x-amz-key-v2"), so a
+// case-sensitive lookup against our lower-case metadata* constants would
+// never match on a real S3 round trip.
+func normalizeMetadataKeys(metadata map[string]*string) map[string]*string {
+	normalized := make(map[string]*string, len(metadata))
+	for k, v := range metadata {
+		normalized[strings.ToLower(k)] = v
+	}
+	return normalized
+}
+
+func envelopeFromMetadata(metadata map[string]*string) (*envelope, error) {
+	wrappedCEK, err := decodeMetadata(metadata, metadataKeyCEK)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := decodeMetadata(metadata, metadataKeyIV)
+	if err != nil {
+		return nil, err
+	}
+	matDesc := metadata[metadataKeyMatDesc]
+	if matDesc == nil {
+		return nil, fmt.Errorf("crypto: object is missing %s metadata", metadataKeyMatDesc)
+	}
+
+	return &envelope{WrappedCEK: wrappedCEK, IV: iv, MatDesc: *matDesc}, nil
+}
+
+func decodeMetadata(metadata map[string]*string, key string) ([]byte, error) {
+	value := metadata[key]
+	if value == nil {
+		return nil, fmt.Errorf("crypto: object is missing %s metadata", key)
+	}
+	return base64.StdEncoding.DecodeString(*value)
+}
@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// fakeS3 implements s3iface.S3API's PutObject/GetObject in memory. Its
+// GetObjectWithContext deliberately returns metadata keys title-cased
+// (e.g. "X-Amz-Key-V2"), mirroring how aws-sdk-go-v1 actually canonicalizes
+// S3 object metadata header names on the way back from a real GetObject
+// call, instead of the exact-case keys PutObject was given.
+type fakeS3 struct {
+	s3iface.S3API
+
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	body     []byte
+	metadata map[string]*string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string]fakeObject)}
+}
+
+func (f *fakeS3) PutObjectWithContext(ctx context.Context, input *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	canonicalized := make(map[string]*string, len(input.Metadata))
+	for k, v := range input.Metadata {
+		canonicalized[canonicalizeHeader(k)] = v
+	}
+
+	f.objects[*input.Bucket+"/"+*input.Key] = fakeObject{body: body, metadata: canonicalized}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(ctx context.Context, input *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	obj, ok := f.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+	return &s3.GetObjectOutput{
+		Body:     io.NopCloser(bytes.NewReader(obj.body)),
+		Metadata: obj.metadata,
+	}, nil
+}
+
+// canonicalizeHeader mimics net/http's header canonicalization (each
+// '-'-separated segment title-cased), which is what aws-sdk-go-v1's S3
+// metadata unmarshal preserves from the raw response header.
+func canonicalizeHeader(key string) string {
+	parts := strings.Split(key, "-")
+	for i, p := range parts {
+		if len(p) == 0 {
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "-")
+}
+
+func newTestEncryptingS3() *EncryptingS3 {
+	return &EncryptingS3{s3: newFakeS3(), kms: fakeKMS{}, kmsKeyID: "test-key-id"}
+}
+
+func TestEncryptingS3RoundTrip(t *testing.T) {
+	e := newTestEncryptingS3()
+	ctx := context.Background()
+
+	plaintext := []byte("object body that must survive encryption")
+	if err := e.upload(ctx, plaintext, "bucket", "key", "text/plain"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	rc, err := e.DownloadFileFromS3(ctx, "bucket", "key")
+	if err != nil {
+		t.Fatalf("DownloadFileFromS3: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read decrypted body: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Fatalf("decrypted body = %q, want %q", got, plaintext)
+	}
+}
+
+func TestEncryptingS3DetectsTamperedObject(t *testing.T) {
+	e := newTestEncryptingS3()
+	ctx := context.Background()
+	fs3 := e.s3.(*fakeS3)
+
+	if err := e.upload(ctx, []byte("object body"), "bucket", "key", "text/plain"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	obj := fs3.objects["bucket/key"]
+	obj.body[0] ^= 0xFF
+	fs3.objects["bucket/key"] = obj
+
+	if _, err := e.DownloadFileFromS3(ctx, "bucket", "key"); err != ErrAuthFailed {
+		t.Fatalf("DownloadFileFromS3(tampered) = %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,121 @@
+package shared
+
+import (
+	"context"
+	"io"
+)
+
+// failoverAWS wraps a primary AWS region plus ordered failover regions.
+// Reads transparently retry against each failover region in turn when the
+// primary returns a region-scoped failure; writes stay pinned to the
+// primary unless WithWriteFailover is set.
+type failoverAWS struct {
+	primary       AWS
+	failovers     []AWS
+	writeFailover bool
+}
+
+func (f *failoverAWS) readCandidates() []AWS {
+	return append([]AWS{f.primary}, f.failovers...)
+}
+
+func (f *failoverAWS) writeCandidates() []AWS {
+	if !f.writeFailover {
+		return []AWS{f.primary}
+	}
+	return f.readCandidates()
+}
+
+// withFailover runs op against each candidate in order, stopping at the
+// first success or the first non-retryable error.
+func withFailover[T any](candidates []AWS, op func(AWS) (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+
+	for _, a := range candidates {
+		result, err := op(a)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if !isFailoverableError(err) {
+			return zero, err
+		}
+	}
+
+	return zero, lastErr
+}
+
+func (f *failoverAWS) GetSecret(secretName string) (map[string]string, error) {
+	return withFailover(f.readCandidates(), func(a AWS) (map[string]string, error) {
+		return a.GetSecret(secretName)
+	})
+}
+
+func (f *failoverAWS) GetSingleSecret(secretName string, value string) (string, error) {
+	return withFailover(f.readCandidates(), func(a AWS) (string, error) {
+		return a.GetSingleSecret(secretName, value)
+	})
+}
+
+func (f *failoverAWS) SetSecretToEnvironmentVariables(secretName string) error {
+	_, err := withFailover(f.readCandidates(), func(a AWS) (struct{}, error) {
+		return struct{}{}, a.SetSecretToEnvironmentVariables(secretName)
+	})
+	return err
+}
+
+// GetQueueUrl is served from the primary region only: the underlying AWS
+// method swallows its error instead of returning it, leaving nothing for
+// withFailover to act on.
+func (f *failoverAWS) GetQueueUrl(v string) string {
+	return f.primary.GetQueueUrl(v)
+}
+
+func (f *failoverAWS) SendStringMessageToSqs(payload string, qURL string) (*string, error) {
+	return withFailover(f.writeCandidates(), func(a AWS) (*string, error) {
+		return a.SendStringMessageToSqs(payload, qURL)
+	})
+}
+
+func (f *failoverAWS) SendStringMessageWithAttributesToSqs(payload string, qURL string, attributes map[string]interface{}) (*string, error) {
+	return withFailover(f.writeCandidates(), func(a AWS) (*string, error) {
+		return a.SendStringMessageWithAttributesToSqs(payload, qURL, attributes)
+	})
+}
+
+func (f *failoverAWS) UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error {
+	_, err := withFailover(f.writeCandidates(), func(a AWS) (struct{}, error) {
+		return struct{}{}, a.UploadFileToS3(path, bucketName, destinationName, contentType)
+	})
+	return err
+}
+
+// DownloadFileFromS3 retries across read candidates the same way the other
+// read methods do, so configuring failover regions also covers S3 GETs.
+func (f *failoverAWS) DownloadFileFromS3(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	return withFailover(f.readCandidates(), func(a AWS) (io.ReadCloser, error) {
+		return a.DownloadFileFromS3(ctx, bucket, key)
+	})
+}
+
+// UploadReader retries across write candidates only when r also implements
+// io.Seeker: a failed attempt may have already consumed part of r, and a
+// non-seekable reader can't be rewound for a safe retry against the next
+// region, so in that case only the primary is attempted.
+func (f *failoverAWS) UploadReader(ctx context.Context, r io.Reader, bucket string, key string, opts UploadOptions) (*UploadResult, error) {
+	candidates := f.writeCandidates()
+	seeker, seekable := r.(io.Seeker)
+	if !seekable && len(candidates) > 1 {
+		candidates = candidates[:1]
+	}
+
+	return withFailover(candidates, func(a AWS) (*UploadResult, error) {
+		if seekable {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		return a.UploadReader(ctx, r, bucket, key, opts)
+	})
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package shared
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// startLocalStack brings up a LocalStack container offering S3, SQS, and
+// Secrets Manager, and returns the endpoint to point ConstructAWS at. It is
+// only compiled in under the "integration" build tag, since it needs Docker
+// and network access that a normal unit-test run doesn't provide.
+func startLocalStack(t *testing.T) string {
+	t.Helper()
+
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "localstack/localstack:3",
+		ExposedPorts: []string{"4566/tcp"},
+		Env: map[string]string{
+			"SERVICES": "s3,sqs,secretsmanager",
+		},
+		WaitingFor: wait.ForLog("Ready.").WithStartupTimeout(2 * time.Minute),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatalf("start localstack: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Logf("terminate localstack: %v", err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("localstack host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "4566")
+	if err != nil {
+		t.Fatalf("localstack port: %v", err)
+	}
+
+	return "http://" + host + ":" + port.Port()
+}
+
+// TestConstructAWSAgainstLocalStack exercises ConstructAWS end-to-end
+// against a real (containerized) S3, rather than mocks: it creates a
+// bucket, uploads through UploadFileToS3, and reads the object back through
+// DownloadFileFromS3, covering the endpoint-override and path-style options
+// together the way a LocalStack-backed caller actually would.
+func TestConstructAWSAgainstLocalStack(t *testing.T) {
+	endpoint := startLocalStack(t)
+
+	a := ConstructAWS(
+		WithRegion("us-east-1"),
+		WithEndpoint(endpoint),
+		WithS3ForcePathStyle(true),
+		WithCredentialsProvider(&credentials.StaticProvider{Value: credentials.Value{AccessKeyID: "test", SecretAccessKey: "test"}}),
+	).(AWS)
+
+	const bucket = "gopkgs-integration-test"
+	if _, err := a.S3.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+
+	const body = "hello from the failover integration test"
+	path := filepath.Join(t.TempDir(), "object.txt")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+
+	if err := a.UploadFileToS3(path, bucket, "object.txt", "text/plain"); err != nil {
+		t.Fatalf("UploadFileToS3: %v", err)
+	}
+
+	rc, err := a.DownloadFileFromS3(context.Background(), bucket, "object.txt")
+	if err != nil {
+		t.Fatalf("DownloadFileFromS3: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read downloaded object: %v", err)
+	}
+	if string(got) != body {
+		t.Fatalf("downloaded body = %q, want %q", got, body)
+	}
+}
@@ -1,14 +1,9 @@
 package shared
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -16,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/iamtito/gopkgs/secrets"
 )
 
 //AwsInterface is an interface that describes AWS interactions
@@ -29,121 +26,90 @@ type AwsInterface interface {
 	UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error
 }
 
-//AWS is a wrapper for a real aws sdk session.
+//AWS is a wrapper for a real aws sdk session. It implements both the legacy
+//AwsInterface and secrets.Provider, backed by the same SecretsManager client.
 type AWS struct {
 	Session        *session.Session
 	SecretsManager *secretsmanager.SecretsManager
 	SQS            *sqs.SQS
 	S3             *s3.S3
-}
 
-func ConstructAWS() AwsInterface {
-	sess := session.Must(session.NewSession(aws.NewConfig().WithRegion("us-east-1")))
-
-	return AWS{
-		Session:        sess,
-		SecretsManager: secretsmanager.New(sess),
-		SQS:            sqs.New(sess),
-		S3:             s3.New(sess),
-	}
+	secretProvider secrets.Provider
 }
 
-func (a AWS) GetSecret(secretName string) (map[string]string, error) {
-	config := make(map[string]string)
-	// Create a context so that the request will timeout before the Lambda does.
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelFn()
-
-	input := &secretsmanager.GetSecretValueInput{
-		SecretId:     aws.String(secretName),
-		VersionStage: aws.String("AWSCURRENT"), // VersionStage defaults to AWSCURRENT if unspecified
+// ConstructAWS builds an AwsInterface for a single region by default.
+// WithFailoverRegions configures transparent regional failover for reads;
+// WithEndpoint/WithS3ForcePathStyle let it target LocalStack, MinIO, or a
+// secondary region during an outage.
+func ConstructAWS(opts ...Option) AwsInterface {
+	cfg := defaultAWSConfig()
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
-	// Grab the secret
-	result, err := a.SecretsManager.GetSecretValueWithContext(ctx, input)
+	primary := buildAWS(cfg, cfg.region)
+	if len(cfg.failoverRegions) == 0 {
+		return primary
+	}
 
-	if err != nil {
-		return config, err
+	failovers := make([]AWS, len(cfg.failoverRegions))
+	for i, region := range cfg.failoverRegions {
+		failovers[i] = buildAWS(cfg, region)
 	}
 
-	// fmt.Println("First printing is here", result)
-
-	// Decrypts secret using the associated KMS CMK.
-	// Depending on whether the secret is a string or binary, one of these fields will be populated.
-	var secretString, decodedBinarySecret string
-	if result.SecretString != nil {
-		secretString = *result.SecretString
-	} else {
-		decodedBinarySecretBytes := make([]byte, base64.StdEncoding.DecodedLen(len(result.SecretBinary)))
-		len, err := base64.StdEncoding.Decode(decodedBinarySecretBytes, result.SecretBinary)
-		if err != nil {
-			fmt.Println("Base64 Decode Error:", err)
-			// return nil
-		}
-		decodedBinarySecret = string(decodedBinarySecretBytes[:len])
-		fmt.Println(decodedBinarySecret)
+	return &failoverAWS{
+		primary:       primary,
+		failovers:     failovers,
+		writeFailover: cfg.writeFailover,
 	}
+}
 
-	json.Unmarshal([]byte(secretString), &config)
-	return config, nil
+// secretsCtx builds a context for callers of AwsInterface's legacy,
+// context-less secret methods. The 10s budget leaves room for the call to
+// return before a Lambda invocation times out; callers that need control
+// over this should use the context-taking Get, GetField, Watch and SetEnv
+// methods below.
+func secretsCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 10*time.Second)
+}
 
+func (a AWS) GetSecret(secretName string) (map[string]string, error) {
+	ctx, cancelFn := secretsCtx()
+	defer cancelFn()
+	return a.secretProvider.Get(ctx, secretName)
 }
 
 //GetSingleSecret obtains a single secret from secretmanager without having to fetch all the secrets
 func (a AWS) GetSingleSecret(secretName string, value string) (string, error) {
-	config := make(map[string]string)
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancelFn := secretsCtx()
 	defer cancelFn()
-
-	input := &secretsmanager.GetSecretValueInput{
-		SecretId:     aws.String(secretName),
-		VersionStage: aws.String("AWSCURRENT"), // VersionStage defaults to AWSCURRENT if unspecified
-	}
-
-	result, err := a.SecretsManager.GetSecretValueWithContext(ctx, input)
-
-	if err != nil {
-		return config[value], err
-	}
-
-	// Decrypts secret using the associated KMS CMK.
-	// Depending on whether the secret is a string or binary, one of these fields will be populated.
-	var secretString, decodedBinarySecret string
-	if result.SecretString != nil {
-		secretString = *result.SecretString
-	} else {
-		decodedBinarySecretBytes := make([]byte, base64.StdEncoding.DecodedLen(len(result.SecretBinary)))
-		len, err := base64.StdEncoding.Decode(decodedBinarySecretBytes, result.SecretBinary)
-		if err != nil {
-			fmt.Println("Base64 Decode Error:", err)
-			// return nil
-		}
-		decodedBinarySecret = string(decodedBinarySecretBytes[:len])
-		fmt.Println(decodedBinarySecret)
-	}
-
-	json.Unmarshal([]byte(secretString), &config)
-	return config[value], nil
-
+	return a.secretProvider.GetField(ctx, secretName, value)
 }
 
 //SetSecretToEnvironmentVariables set the secret environment variable
 func (a AWS) SetSecretToEnvironmentVariables(secretName string) error {
-	config, err := a.GetSecret(secretName)
+	ctx, cancelFn := secretsCtx()
+	defer cancelFn()
+	return a.secretProvider.SetEnv(ctx, secretName, "")
+}
 
-	if err != nil {
-		return err
-	}
+// Get, GetField, Watch and SetEnv implement secrets.Provider directly,
+// taking a caller-supplied context instead of the hardcoded 10s timeout
+// used by the legacy AwsInterface methods above.
+func (a AWS) Get(ctx context.Context, name string) (map[string]string, error) {
+	return a.secretProvider.Get(ctx, name)
+}
 
-	for key, value := range config {
-		if err := os.Setenv(key, value); err != nil {
-			return err
-		}
-	}
+func (a AWS) GetField(ctx context.Context, name string, key string) (string, error) {
+	return a.secretProvider.GetField(ctx, name, key)
+}
+
+func (a AWS) Watch(ctx context.Context, name string) (<-chan map[string]string, error) {
+	return a.secretProvider.Watch(ctx, name)
+}
 
-	return nil
+func (a AWS) SetEnv(ctx context.Context, name string, prefix string) error {
+	return a.secretProvider.SetEnv(ctx, name, prefix)
 }
 
 //GetQueueUrl obtains a SQS url
@@ -151,39 +117,32 @@ func (a AWS) GetQueueUrl(v string) string {
 	result, err := a.SQS.GetQueueUrl(&sqs.GetQueueUrlInput{
 		QueueName: aws.String(v), // Required
 	})
-	// resp, err := svc.GetQueueURL(result)
 	if err != nil {
 		fmt.Println(err)
+		return ""
 	}
 	return *result.QueueUrl
 }
 
 //SendStringMessageToSqs enqueue a message to AWS SQS Queue
 func (a AWS) SendStringMessageToSqs(payload string, qURL string) (*string, error) {
-	var err error
-	result, err := a.SQS.SendMessage(&sqs.SendMessageInput{
-		MessageBody: aws.String(payload),
-		QueueUrl:    &qURL,
-	})
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
 
+	result, err := sendMessage(ctx, a.SQS, qURL, payload, rawCodec{}, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	if result.MessageId == nil {
-		err = errors.New("Message was not sent. Payload" + payload)
+	if result == nil {
+		return nil, errors.New("Message was not sent. Payload" + payload)
 	}
-
-	return result.MessageId, err
+	return result, nil
 }
 
 //SendStringMessageWithAttributesToSqs enqueue a message via SQS, but also you can set the message attributes
 func (a AWS) SendStringMessageWithAttributesToSqs(payload string, qURL string, attributes map[string]interface{}) (*string, error) {
-	var err error
-
-	messageAttributes := map[string]*sqs.MessageAttributeValue{}
-
 	// convert the attribute map to aws format
+	messageAttributes := make(map[string]*sqs.MessageAttributeValue, len(attributes))
 	for k, v := range attributes {
 		messageAttributes[k] = &sqs.MessageAttributeValue{
 			DataType:    aws.String("String"),
@@ -191,62 +150,16 @@ func (a AWS) SendStringMessageWithAttributesToSqs(payload string, qURL string, a
 		}
 	}
 
-	result, err := a.SQS.SendMessage(&sqs.SendMessageInput{
-		MessageBody:       aws.String(payload),
-		MessageAttributes: messageAttributes,
-		QueueUrl:          &qURL,
-	})
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
 
+	result, err := sendMessage(ctx, a.SQS, qURL, payload, rawCodec{}, messageAttributes)
 	if err != nil {
 		return nil, err
 	}
-
-	if result.MessageId == nil {
-		err = errors.New("Message was not sent. Payload" + payload)
+	if result == nil {
+		return nil, errors.New("Message was not sent. Payload" + payload)
 	}
-
-	return result.MessageId, err
+	return result, nil
 }
 
-//UploadFileToS3 Upload a file to S3
-func (a AWS) UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error {
-	file, err := os.Open(path)
-
-	if err != nil {
-		return err
-	}
-
-	defer file.Close()
-
-	// Get the file metadata
-	fileInfo, _ := file.Stat()
-	var size = fileInfo.Size()
-	buffer := make([]byte, size)
-	file.Read(buffer)
-
-	// Clean up the bucket name
-	cleanedUpBucketName := bucketName
-
-	if strings.Contains(cleanedUpBucketName, ":") {
-		bucketParts := strings.Split(cleanedUpBucketName, ":")
-		cleanedUpBucketName = bucketParts[len(bucketParts)-1]
-	}
-
-	// Create a context so that the request will timeout before the Lambda does.
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelFn()
-
-	_, err = a.S3.PutObjectWithContext(ctx, &s3.PutObjectInput{
-		Bucket:               aws.String(cleanedUpBucketName),
-		Key:                  aws.String(destinationName),
-		ACL:                  aws.String("private"),
-		Body:                 bytes.NewReader(buffer),
-		ContentLength:        aws.Int64(size),
-		ContentType:          aws.String(contentType),
-		ContentDisposition:   aws.String("attachment"),
-		ServerSideEncryption: aws.String("AES256"),
-	})
-
-	return err
-}
@@ -0,0 +1,163 @@
+package shared
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/iamtito/gopkgs/secrets"
+)
+
+// endpoints holds per-service endpoint overrides, used to target LocalStack,
+// MinIO, or any other S3/SQS/SecretsManager-compatible endpoint.
+type endpoints struct {
+	All            string
+	S3             string
+	SQS            string
+	SecretsManager string
+}
+
+func (e endpoints) forS3() string             { return firstNonEmpty(e.S3, e.All) }
+func (e endpoints) forSQS() string            { return firstNonEmpty(e.SQS, e.All) }
+func (e endpoints) forSecretsManager() string { return firstNonEmpty(e.SecretsManager, e.All) }
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// awsConfig accumulates the options passed to ConstructAWS.
+type awsConfig struct {
+	region string
+
+	endpoints        endpoints
+	s3ForcePathStyle bool
+
+	credentialsProvider credentials.Provider
+
+	failoverRegions []string
+	writeFailover   bool
+}
+
+// Option configures ConstructAWS.
+type Option func(*awsConfig)
+
+// WithRegion sets the primary AWS region. Defaults to "us-east-1".
+func WithRegion(region string) Option {
+	return func(c *awsConfig) { c.region = region }
+}
+
+// WithEndpoint overrides the endpoint used for every service (S3, SQS,
+// SecretsManager). Use the per-service With*Endpoint options to override
+// just one.
+func WithEndpoint(url string) Option {
+	return func(c *awsConfig) { c.endpoints.All = url }
+}
+
+// WithS3Endpoint overrides only the S3 endpoint.
+func WithS3Endpoint(url string) Option {
+	return func(c *awsConfig) { c.endpoints.S3 = url }
+}
+
+// WithSQSEndpoint overrides only the SQS endpoint.
+func WithSQSEndpoint(url string) Option {
+	return func(c *awsConfig) { c.endpoints.SQS = url }
+}
+
+// WithSecretsManagerEndpoint overrides only the Secrets Manager endpoint.
+func WithSecretsManagerEndpoint(url string) Option {
+	return func(c *awsConfig) { c.endpoints.SecretsManager = url }
+}
+
+// WithS3ForcePathStyle enables path-style S3 addressing, required by MinIO
+// and most other S3-compatible endpoints.
+func WithS3ForcePathStyle(force bool) Option {
+	return func(c *awsConfig) { c.s3ForcePathStyle = force }
+}
+
+// WithCredentialsProvider overrides how AWS credentials are resolved.
+func WithCredentialsProvider(provider credentials.Provider) Option {
+	return func(c *awsConfig) { c.credentialsProvider = provider }
+}
+
+// WithFailoverRegions configures one or more regions to fall back to, in
+// order, when a read against the primary region fails with a
+// RequestError, EndpointConnectionError, or 5xx response.
+func WithFailoverRegions(regions []string) Option {
+	return func(c *awsConfig) { c.failoverRegions = regions }
+}
+
+// WithWriteFailover allows writes (SendStringMessageToSqs, UploadFileToS3, ...) to also
+// fall back to a failover region. Writes are pinned to the primary region
+// by default, since a regional failover can leave writes in a
+// not-yet-replicated state.
+func WithWriteFailover(enabled bool) Option {
+	return func(c *awsConfig) { c.writeFailover = enabled }
+}
+
+func defaultAWSConfig() *awsConfig {
+	return &awsConfig{region: "us-east-1"}
+}
+
+// buildAWS constructs an AWS wrapper targeting region, applying every
+// endpoint and credentials override from cfg.
+func buildAWS(cfg *awsConfig, region string) AWS {
+	awsCfg := aws.NewConfig().WithRegion(region)
+	if cfg.credentialsProvider != nil {
+		awsCfg = awsCfg.WithCredentials(credentials.NewCredentials(cfg.credentialsProvider))
+	}
+	if cfg.s3ForcePathStyle {
+		awsCfg = awsCfg.WithS3ForcePathStyle(true)
+	}
+	sess := session.Must(session.NewSession(awsCfg))
+
+	var smOverride, sqsOverride, s3Override []*aws.Config
+	if ep := cfg.endpoints.forSecretsManager(); ep != "" {
+		smOverride = append(smOverride, aws.NewConfig().WithEndpoint(ep))
+	}
+	if ep := cfg.endpoints.forSQS(); ep != "" {
+		sqsOverride = append(sqsOverride, aws.NewConfig().WithEndpoint(ep))
+	}
+	if ep := cfg.endpoints.forS3(); ep != "" {
+		s3Override = append(s3Override, aws.NewConfig().WithEndpoint(ep))
+	}
+
+	secretsManager := secretsmanager.New(sess, smOverride...)
+
+	return AWS{
+		Session:        sess,
+		SecretsManager: secretsManager,
+		SQS:            sqs.New(sess, sqsOverride...),
+		S3:             s3.New(sess, s3Override...),
+		secretProvider: secrets.NewFromClient(secretsManager),
+	}
+}
+
+// isFailoverableError reports whether err is the kind of transient,
+// region-scoped failure that justifies falling back to another region:
+// network-level request errors or a 5xx response.
+func isFailoverableError(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	switch aerr.Code() {
+	case "RequestError", "EndpointConnectionError":
+		return true
+	}
+
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() >= 500
+	}
+
+	return false
+}
@@ -0,0 +1,222 @@
+package shared
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Interface describes the subset of shared.AWS used for S3 object
+// storage. It exists so alternative implementations (such as
+// crypto.EncryptingS3) can be swapped in wherever a caller only needs S3
+// access.
+type S3Interface interface {
+	UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error
+	DownloadFileFromS3(ctx context.Context, bucket string, key string) (io.ReadCloser, error)
+}
+
+// UploadOptions configures a streaming upload via UploadReader.
+type UploadOptions struct {
+	ContentType        string
+	ContentDisposition string
+	ACL                string
+
+	// PartSizeBytes and Concurrency tune the underlying s3manager.Uploader.
+	// Zero values fall back to the SDK's defaults (5MB parts, 5 concurrent
+	// parts).
+	PartSizeBytes int64
+	Concurrency   int
+
+	// ServerSideEncryption is "AES256" or "aws:kms". Defaults to "AES256".
+	ServerSideEncryption string
+	// SSEKMSKeyId is only used when ServerSideEncryption is "aws:kms". An
+	// empty value lets S3 use the account's default CMK.
+	SSEKMSKeyId string
+
+	// MaxRetries bounds the number of attempts made when the upload fails
+	// with a retryable error. Defaults to 3.
+	MaxRetries int
+}
+
+func (o UploadOptions) withDefaults() UploadOptions {
+	if o.ServerSideEncryption == "" {
+		o.ServerSideEncryption = s3.ServerSideEncryptionAes256
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+	return o
+}
+
+// UploadResult describes the outcome of a successful UploadReader call.
+type UploadResult struct {
+	ETag             string
+	VersionID        string
+	BytesTransferred int64
+}
+
+// UploadReader streams r to bucket/key using the S3 multipart uploader, so
+// the whole payload never has to fit in memory at once. It retries
+// transient failures (RequestTimeout, SlowDown, response timeouts, network
+// request errors, and 5xx responses) with exponential backoff.
+func (a AWS) UploadReader(ctx context.Context, r io.Reader, bucket string, key string, opts UploadOptions) (*UploadResult, error) {
+	opts = opts.withDefaults()
+
+	uploader := s3manager.NewUploaderWithClient(a.S3, func(u *s3manager.Uploader) {
+		if opts.PartSizeBytes > 0 {
+			u.PartSize = opts.PartSizeBytes
+		}
+		if opts.Concurrency > 0 {
+			u.Concurrency = opts.Concurrency
+		}
+	})
+
+	input := &s3manager.UploadInput{
+		Bucket:               aws.String(cleanBucketName(bucket)),
+		Key:                  aws.String(key),
+		Body:                 &countingReader{r: r},
+		ServerSideEncryption: aws.String(opts.ServerSideEncryption),
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
+	if opts.ACL != "" {
+		input.ACL = aws.String(opts.ACL)
+	}
+	if opts.ServerSideEncryption == s3.ServerSideEncryptionAwsKms && opts.SSEKMSKeyId != "" {
+		input.SSEKMSKeyId = aws.String(opts.SSEKMSKeyId)
+	}
+
+	counter := input.Body.(*countingReader)
+
+	var lastErr error
+	for attempt := 0; attempt < opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if !counter.seekToStart() {
+				// The underlying reader isn't seekable, so we can't safely
+				// retry after a partial read.
+				return nil, lastErr
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+
+		output, err := uploader.UploadWithContext(ctx, input)
+		if err == nil {
+			return &UploadResult{
+				ETag:             aws.StringValue(output.ETag),
+				VersionID:        aws.StringValue(output.VersionID),
+				BytesTransferred: counter.n,
+			}, nil
+		}
+
+		lastErr = err
+		if !isRetryableS3Error(err) {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+//UploadFileToS3 Upload a file to S3
+func (a AWS) UploadFileToS3(path string, bucketName string, destinationName string, contentType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	_, err = a.UploadReader(ctx, file, bucketName, destinationName, UploadOptions{
+		ContentType:        contentType,
+		ContentDisposition: "attachment",
+		ACL:                "private",
+	})
+	return err
+}
+
+//DownloadFileFromS3 fetches an object from S3 and returns its body as a
+//stream; the caller is responsible for closing it.
+func (a AWS) DownloadFileFromS3(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	output, err := a.S3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(cleanBucketName(bucket)),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func cleanBucketName(bucketName string) string {
+	if !strings.Contains(bucketName, ":") {
+		return bucketName
+	}
+	parts := strings.Split(bucketName, ":")
+	return parts[len(parts)-1]
+}
+
+func backoff(attempt int) time.Duration {
+	return time.Duration(math.Pow(2, float64(attempt))) * 100 * time.Millisecond
+}
+
+func isRetryableS3Error(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	switch aerr.Code() {
+	case "RequestTimeout", "SlowDown", request.ErrCodeResponseTimeout, request.ErrCodeRequestError:
+		return true
+	}
+
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() >= 500
+	}
+
+	return false
+}
+
+// countingReader wraps an io.Reader to track bytes read, and supports being
+// rewound via seekToStart when the wrapped reader is also an io.Seeker.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) seekToStart() bool {
+	seeker, ok := c.r.(io.Seeker)
+	if !ok {
+		return false
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return false
+	}
+	c.n = 0
+	return true
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func init() {
+	Register(KindAWS, newAWSProvider)
+}
+
+// awsProvider implements Provider on top of AWS Secrets Manager.
+type awsProvider struct {
+	client *secretsmanager.SecretsManager
+}
+
+func newAWSProvider(opts ...Option) (Provider, error) {
+	o := buildOptions(opts...)
+
+	cfg := aws.NewConfig()
+	if o.Region != "" {
+		cfg = cfg.WithRegion(o.Region)
+	}
+	sess := session.Must(session.NewSession(cfg))
+
+	var p Provider = &awsProvider{client: secretsmanager.New(sess)}
+	if o.CacheTTLSeconds > 0 {
+		p = withCache(p, time.Duration(o.CacheTTLSeconds)*time.Second)
+	}
+	return p, nil
+}
+
+// NewFromClient builds a Provider around a SecretsManager client the caller
+// already owns, instead of constructing its own session. This lets the
+// legacy shared.AWS wrapper reuse its existing session.
+func NewFromClient(client *secretsmanager.SecretsManager) Provider {
+	return &awsProvider{client: client}
+}
+
+func (a *awsProvider) Get(ctx context.Context, name string) (map[string]string, error) {
+	result, err := a.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(name),
+		VersionStage: aws.String("AWSCURRENT"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var secretString string
+	if result.SecretString != nil {
+		secretString = *result.SecretString
+	} else {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(result.SecretBinary)))
+		n, err := base64.StdEncoding.Decode(decoded, result.SecretBinary)
+		if err != nil {
+			return nil, err
+		}
+		secretString = string(decoded[:n])
+	}
+
+	config := make(map[string]string)
+	if err := json.Unmarshal([]byte(secretString), &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (a *awsProvider) GetField(ctx context.Context, name string, key string) (string, error) {
+	values, err := a.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+// Watch polls Secrets Manager for version changes, since AWS has no push
+// notification for rotations. It emits the current values whenever the
+// VersionId changes and stops when ctx is cancelled.
+func (a *awsProvider) Watch(ctx context.Context, name string) (<-chan map[string]string, error) {
+	ch := make(chan map[string]string)
+
+	go func() {
+		defer close(ch)
+
+		var lastVersion *string
+		seenFirst := false
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			result, err := a.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: aws.String(name),
+			})
+			if err == nil && (lastVersion == nil || *lastVersion != aws.StringValue(result.VersionId)) {
+				lastVersion = result.VersionId
+				if !seenFirst {
+					seenFirst = true
+				} else if values, err := a.Get(ctx, name); err == nil {
+					select {
+					case ch <- values:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (a *awsProvider) SetEnv(ctx context.Context, name string, prefix string) error {
+	return setEnv(ctx, a, name, prefix)
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachingProvider wraps a Provider and remembers the result of Get/GetField
+// for ttl, so repeated Lambda invocations within the same cold start don't
+// re-hit the backend on every call.
+type cachingProvider struct {
+	Provider
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	values    map[string]string
+	expiresAt time.Time
+}
+
+func withCache(p Provider, ttl time.Duration) Provider {
+	if ttl <= 0 {
+		return p
+	}
+	return &cachingProvider{
+		Provider: p,
+		ttl:      ttl,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (c *cachingProvider) Get(ctx context.Context, name string) (map[string]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.values, nil
+	}
+
+	values, err := c.Provider.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[name] = cacheEntry{values: values, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return values, nil
+}
+
+func (c *cachingProvider) GetField(ctx context.Context, name string, key string) (string, error) {
+	values, err := c.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+func (c *cachingProvider) SetEnv(ctx context.Context, name string, prefix string) error {
+	return setEnv(ctx, c, name, prefix)
+}
+
+// Watch overrides the embedded Provider's Watch so that every rotation it
+// observes also invalidates the cache entry for name, instead of leaving
+// Get to serve a stale value until ttl expires.
+func (c *cachingProvider) Watch(ctx context.Context, name string) (<-chan map[string]string, error) {
+	upstream, err := c.Provider.Watch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan map[string]string)
+	go func() {
+		defer close(out)
+		for values := range upstream {
+			c.invalidate(name)
+			select {
+			case out <- values:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// invalidate drops the cached entry for name, used when Watch observes a
+// rotation so the next Get reflects the new values immediately.
+func (c *cachingProvider) invalidate(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	c.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingProvider wraps a MockProvider to count calls that actually reach
+// the backend, so tests can tell a cache hit from a cache miss instead of
+// just inspecting the (identical) returned values.
+type countingProvider struct {
+	*MockProvider
+
+	mu       sync.Mutex
+	getCalls int
+}
+
+func (c *countingProvider) Get(ctx context.Context, name string) (map[string]string, error) {
+	c.mu.Lock()
+	c.getCalls++
+	c.mu.Unlock()
+	return c.MockProvider.Get(ctx, name)
+}
+
+func (c *countingProvider) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getCalls
+}
+
+func TestCachingProviderServesFromCacheWithinTTL(t *testing.T) {
+	backend := &countingProvider{MockProvider: NewMockProvider(map[string]map[string]string{
+		"db": {"password": "v1"},
+	})}
+	cached := withCache(backend, time.Hour)
+
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if got := backend.calls(); got != 1 {
+		t.Fatalf("backend Get called %d times, want 1 (second Get should hit the cache)", got)
+	}
+}
+
+func TestCachingProviderRefetchesAfterTTLExpires(t *testing.T) {
+	backend := &countingProvider{MockProvider: NewMockProvider(map[string]map[string]string{
+		"db": {"password": "v1"},
+	})}
+	cached := withCache(backend, 10*time.Millisecond)
+
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if got := backend.calls(); got != 2 {
+		t.Fatalf("backend Get called %d times, want 2 (cache entry should have expired)", got)
+	}
+}
+
+func TestCachingProviderWatchInvalidatesOnRotation(t *testing.T) {
+	backend := &countingProvider{MockProvider: NewMockProvider(map[string]map[string]string{
+		"db": {"password": "v1"},
+	})}
+	cached := withCache(backend, time.Hour).(*cachingProvider)
+
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := backend.calls(); got != 1 {
+		t.Fatalf("backend Get called %d times, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := cached.Watch(ctx, "db")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	backend.MockProvider.Set("db", map[string]string{"password": "v2"})
+
+	select {
+	case values := <-ch:
+		if values["password"] != "v2" {
+			t.Fatalf("Watch delivered %v, want password=v2", values)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not deliver the rotation in time")
+	}
+
+	// The long TTL means a third call only avoids the backend if the cache
+	// entry is still considered fresh; Watch's invalidation should have
+	// dropped it, so this Get must reach the backend again.
+	if _, err := cached.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := backend.calls(); got != 2 {
+		t.Fatalf("backend Get called %d times after rotation, want 2 (cache should have been invalidated)", got)
+	}
+}
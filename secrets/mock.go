@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MockProvider is an in-memory Provider intended for unit tests. Downstream
+// code that only depends on Provider can exercise secret handling without
+// pulling in AWS, Vault, or GCP mocks.
+type MockProvider struct {
+	mu          sync.Mutex
+	secrets     map[string]map[string]string
+	subscribers map[string][]chan map[string]string
+}
+
+// NewMockProvider returns a MockProvider seeded with the given secrets.
+func NewMockProvider(seed map[string]map[string]string) *MockProvider {
+	m := &MockProvider{secrets: make(map[string]map[string]string)}
+	for name, values := range seed {
+		m.secrets[name] = values
+	}
+	return m
+}
+
+// Set replaces the values stored under name, notifying any active Watch
+// subscribers. The send loop runs under m.mu, the same lock Watch's cleanup
+// goroutine holds while removing and closing a cancelled subscriber's
+// channel: sending to ch and closing ch would otherwise race (select with a
+// default case only guards a full buffer, not a closed channel, so a send
+// that lost that race would still panic).
+func (m *MockProvider) Set(name string, values map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.secrets[name] = values
+
+	for _, ch := range m.subscribers[name] {
+		select {
+		case ch <- values:
+		default:
+			// Subscriber isn't reading (e.g. its Watch context already
+			// ended); drop the update rather than block Set forever.
+		}
+	}
+}
+
+func (m *MockProvider) Get(ctx context.Context, name string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values, ok := m.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("secrets: no mock secret named %q", name)
+	}
+	return values, nil
+}
+
+func (m *MockProvider) GetField(ctx context.Context, name string, key string) (string, error) {
+	values, err := m.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+func (m *MockProvider) Watch(ctx context.Context, name string) (<-chan map[string]string, error) {
+	ch := make(chan map[string]string, 1)
+
+	m.mu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[string][]chan map[string]string)
+	}
+	m.subscribers[name] = append(m.subscribers[name], ch)
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		subs := m.subscribers[name]
+		for i, c := range subs {
+			if c == ch {
+				m.subscribers[name] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+func (m *MockProvider) SetEnv(ctx context.Context, name string, prefix string) error {
+	return setEnv(ctx, m, name, prefix)
+}
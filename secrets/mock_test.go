@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMockProviderGetReturnsSeededValues(t *testing.T) {
+	m := NewMockProvider(map[string]map[string]string{
+		"db": {"password": "hunter2"},
+	})
+
+	values, err := m.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if values["password"] != "hunter2" {
+		t.Fatalf("Get = %v, want password=hunter2", values)
+	}
+
+	if _, err := m.Get(context.Background(), "missing"); err == nil {
+		t.Fatal("Get(missing) = nil error, want error")
+	}
+}
+
+func TestMockProviderGetField(t *testing.T) {
+	m := NewMockProvider(map[string]map[string]string{
+		"db": {"password": "hunter2"},
+	})
+
+	got, err := m.GetField(context.Background(), "db", "password")
+	if err != nil {
+		t.Fatalf("GetField: %v", err)
+	}
+	if got != "hunter2" {
+		t.Fatalf("GetField = %q, want %q", got, "hunter2")
+	}
+}
+
+func TestMockProviderSetNotifiesWatch(t *testing.T) {
+	m := NewMockProvider(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := m.Watch(ctx, "db")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	m.Set("db", map[string]string{"password": "rotated"})
+
+	select {
+	case values := <-ch:
+		if values["password"] != "rotated" {
+			t.Fatalf("Watch delivered %v, want password=rotated", values)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not deliver the Set update in time")
+	}
+}
+
+func TestMockProviderWatchClosesOnContextCancel(t *testing.T) {
+	m := NewMockProvider(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := m.Watch(ctx, "db")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("Watch channel delivered a value instead of closing")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch channel did not close after context cancellation")
+	}
+}
+
+// TestMockProviderSetDoesNotPanicOnConcurrentWatchCancel exercises the race
+// between Set's send loop and Watch's cleanup goroutine closing a cancelled
+// subscriber's channel: Set must never send on a channel the cleanup
+// goroutine has already closed.
+func TestMockProviderSetDoesNotPanicOnConcurrentWatchCancel(t *testing.T) {
+	m := NewMockProvider(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		if _, err := m.Watch(ctx, "db"); err != nil {
+			t.Fatalf("Watch: %v", err)
+		}
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cancel()
+		}()
+		go func() {
+			defer wg.Done()
+			m.Set("db", map[string]string{"n": "1"})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+// Package secrets provides a pluggable abstraction over secret backends
+// (AWS Secrets Manager, HashiCorp Vault, GCP Secret Manager) so that
+// callers don't have to hard-code against a single provider.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Kind identifies a secret backend implementation.
+type Kind string
+
+const (
+	KindAWS   Kind = "aws"
+	KindVault Kind = "vault"
+	KindGCP   Kind = "gcp"
+)
+
+// Provider is implemented by every secret backend supported by this package.
+type Provider interface {
+	// Get fetches every key/value pair stored under name.
+	Get(ctx context.Context, name string) (map[string]string, error)
+	// GetField fetches a single key out of the secret stored under name.
+	GetField(ctx context.Context, name string, key string) (string, error)
+	// Watch streams updated values for name whenever the backend reports a
+	// rotation. The channel is closed when ctx is cancelled.
+	Watch(ctx context.Context, name string) (<-chan map[string]string, error)
+	// SetEnv sets every key/value pair of the secret as an environment
+	// variable, optionally prefixed.
+	SetEnv(ctx context.Context, name string, prefix string) error
+}
+
+// Factory builds a Provider from a set of Options. Providers register
+// themselves via Register so New can be extended without modifying this
+// package.
+type Factory func(opts ...Option) (Provider, error)
+
+var registry = map[Kind]Factory{}
+
+// Register associates a Kind with the Factory used to construct it. It is
+// typically called from an init() in the file that implements the backend.
+func Register(kind Kind, factory Factory) {
+	registry[kind] = factory
+}
+
+// New builds a Provider for kind using opts. It returns an error if kind has
+// not been registered.
+func New(kind Kind, opts ...Option) (Provider, error) {
+	factory, ok := registry[kind]
+	if !ok {
+		return nil, fmt.Errorf("secrets: unknown provider kind %q", kind)
+	}
+	return factory(opts...)
+}
+
+// Options configure a Provider at construction time. Not every field is
+// meaningful to every backend; each Factory reads only what it needs.
+type Options struct {
+	Region string
+
+	VaultAddress string
+	VaultRoleID  string
+	VaultSecretID string
+	VaultToken   string
+
+	GCPProjectID string
+
+	CacheTTLSeconds int
+}
+
+// Option mutates Options during New.
+type Option func(*Options)
+
+// WithRegion sets the AWS region used by the aws backend.
+func WithRegion(region string) Option {
+	return func(o *Options) { o.Region = region }
+}
+
+// WithVaultAddress sets the Vault server address used by the vault backend.
+func WithVaultAddress(addr string) Option {
+	return func(o *Options) { o.VaultAddress = addr }
+}
+
+// WithVaultAppRole configures AppRole auth for the vault backend.
+func WithVaultAppRole(roleID, secretID string) Option {
+	return func(o *Options) {
+		o.VaultRoleID = roleID
+		o.VaultSecretID = secretID
+	}
+}
+
+// WithVaultToken configures static token auth for the vault backend,
+// primarily useful for local development and tests.
+func WithVaultToken(token string) Option {
+	return func(o *Options) { o.VaultToken = token }
+}
+
+// WithGCPProjectID sets the project used by the gcp backend.
+func WithGCPProjectID(projectID string) Option {
+	return func(o *Options) { o.GCPProjectID = projectID }
+}
+
+// WithCacheTTLSeconds wraps the resulting Provider in a caching layer with
+// the given TTL. A TTL of 0 disables caching (the default).
+func WithCacheTTLSeconds(seconds int) Option {
+	return func(o *Options) { o.CacheTTLSeconds = seconds }
+}
+
+func buildOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, apply := range opts {
+		apply(o)
+	}
+	return o
+}
+
+// setEnv is shared by every backend's SetEnv implementation.
+func setEnv(ctx context.Context, p Provider, name, prefix string) error {
+	values, err := p.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if err := os.Setenv(prefix+key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func init() {
+	Register(KindVault, newVaultProvider)
+}
+
+// vaultProvider implements Provider on top of Vault's KV v2 secrets engine.
+type vaultProvider struct {
+	client *vault.Client
+}
+
+func newVaultProvider(opts ...Option) (Provider, error) {
+	o := buildOptions(opts...)
+
+	cfg := vault.DefaultConfig()
+	if o.VaultAddress != "" {
+		cfg.Address = o.VaultAddress
+	}
+
+	client, err := vault.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: vault client: %w", err)
+	}
+
+	switch {
+	case o.VaultToken != "":
+		client.SetToken(o.VaultToken)
+	case o.VaultRoleID != "" && o.VaultSecretID != "":
+		if err := approleLogin(client, o.VaultRoleID, o.VaultSecretID); err != nil {
+			return nil, err
+		}
+	}
+
+	var p Provider = &vaultProvider{client: client}
+	if o.CacheTTLSeconds > 0 {
+		p = withCache(p, time.Duration(o.CacheTTLSeconds)*time.Second)
+	}
+	return p, nil
+}
+
+func approleLogin(client *vault.Client, roleID, secretID string) error {
+	resp, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+	if err != nil {
+		return fmt.Errorf("secrets: approle login: %w", err)
+	}
+	if resp == nil || resp.Auth == nil {
+		return fmt.Errorf("secrets: approle login returned no auth info")
+	}
+	client.SetToken(resp.Auth.ClientToken)
+	return nil
+}
+
+// kvMount is the KV v2 mount path used for every secret. Vault's KV v2 API
+// nests the actual data under "data/<path>".
+const kvMount = "secret"
+
+func (v *vaultProvider) Get(ctx context.Context, name string) (map[string]string, error) {
+	secret, err := v.client.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/data/%s", kvMount, name))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secrets: no secret found at %q", name)
+	}
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("secrets: unexpected KV v2 response shape for %q", name)
+	}
+
+	values := make(map[string]string, len(data))
+	for k, raw := range data {
+		s, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		values[k] = s
+	}
+	return values, nil
+}
+
+func (v *vaultProvider) GetField(ctx context.Context, name string, key string) (string, error) {
+	values, err := v.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return values[key], nil
+}
+
+// Watch polls Vault for changes to the secret's metadata version, since KV v2
+// does not push rotations to clients.
+func (v *vaultProvider) Watch(ctx context.Context, name string) (<-chan map[string]string, error) {
+	ch := make(chan map[string]string)
+
+	go func() {
+		defer close(ch)
+
+		var lastVersion interface{}
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			meta, err := v.client.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/metadata/%s", kvMount, name))
+			if err == nil && meta != nil {
+				version := meta.Data["current_version"]
+				if lastVersion == nil {
+					lastVersion = version
+				} else if version != lastVersion {
+					lastVersion = version
+					if values, err := v.Get(ctx, name); err == nil {
+						select {
+						case ch <- values:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (v *vaultProvider) SetEnv(ctx context.Context, name string, prefix string) error {
+	return setEnv(ctx, v, name, prefix)
+}
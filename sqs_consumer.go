@@ -0,0 +1,171 @@
+package shared
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Handler processes a single SQS message. Returning an error leaves the
+// message for SQS to redeliver (and eventually move to a redrive/DLQ policy
+// configured on the queue itself); returning nil deletes it.
+type Handler func(ctx context.Context, msg *sqs.Message) error
+
+// ConsumerOption configures an SQSConsumer.
+type ConsumerOption func(*SQSConsumer)
+
+// WithWaitTimeSeconds sets the long-poll wait time, up to 20. Defaults to 20.
+func WithWaitTimeSeconds(seconds int64) ConsumerOption {
+	return func(c *SQSConsumer) { c.waitTimeSeconds = seconds }
+}
+
+// WithVisibilityTimeout sets the per-message visibility timeout. While a
+// handler is still running the timeout is extended every 80% of this
+// duration. Defaults to 30s.
+func WithVisibilityTimeout(seconds int64) ConsumerOption {
+	return func(c *SQSConsumer) { c.visibilityTimeout = seconds }
+}
+
+// WithMaxMessagesPerPoll sets how many messages to request per
+// ReceiveMessage call, up to 10. Defaults to 10.
+func WithMaxMessagesPerPoll(n int64) ConsumerOption {
+	return func(c *SQSConsumer) { c.maxMessages = n }
+}
+
+// WithWorkerPoolSize sets how many messages are handled concurrently.
+// Defaults to 1.
+func WithWorkerPoolSize(n int) ConsumerOption {
+	return func(c *SQSConsumer) { c.workers = n }
+}
+
+// SQSConsumer long-polls a queue and dispatches messages to a worker pool,
+// extending each message's visibility timeout for as long as its handler is
+// still running.
+type SQSConsumer struct {
+	sqs      *sqs.SQS
+	queueURL string
+
+	waitTimeSeconds   int64
+	visibilityTimeout int64
+	maxMessages       int64
+	workers           int
+}
+
+// NewSQSConsumer returns a consumer that polls queueURL using client.
+func NewSQSConsumer(client *sqs.SQS, queueURL string, opts ...ConsumerOption) *SQSConsumer {
+	c := &SQSConsumer{
+		sqs:               client,
+		queueURL:          queueURL,
+		waitTimeSeconds:   20,
+		visibilityTimeout: 30,
+		maxMessages:       10,
+		workers:           1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run polls for messages and dispatches them to handler until ctx is
+// cancelled or a ReceiveMessage call fails, at which point it waits for
+// in-flight handlers to finish before returning ctx.Err() or the receive
+// error.
+func (c *SQSConsumer) Run(ctx context.Context, handler Handler) error {
+	jobs := make(chan *sqs.Message)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go c.worker(ctx, jobs, handler, &wg)
+	}
+
+	err := c.poll(ctx, jobs)
+	close(jobs)
+	wg.Wait()
+	return err
+}
+
+func (c *SQSConsumer) poll(ctx context.Context, jobs chan<- *sqs.Message) error {
+	for {
+		result, err := c.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(c.queueURL),
+			MaxNumberOfMessages:   aws.Int64(c.maxMessages),
+			WaitTimeSeconds:       aws.Int64(c.waitTimeSeconds),
+			VisibilityTimeout:     aws.Int64(c.visibilityTimeout),
+			MessageAttributeNames: aws.StringSlice([]string{"All"}),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		for _, msg := range result.Messages {
+			select {
+			case jobs <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *SQSConsumer) worker(ctx context.Context, jobs <-chan *sqs.Message, handler Handler, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for msg := range jobs {
+		c.handle(ctx, msg, handler)
+	}
+}
+
+func (c *SQSConsumer) handle(ctx context.Context, msg *sqs.Message, handler Handler) {
+	done := make(chan struct{})
+	go c.extendVisibility(ctx, msg, done)
+
+	err := handler(ctx, msg)
+	close(done)
+	if err != nil {
+		return
+	}
+
+	c.sqs.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.queueURL),
+		ReceiptHandle: msg.ReceiptHandle,
+	})
+}
+
+// extendVisibility keeps renewing msg's visibility timeout at 80% of its
+// configured duration for as long as the handler is still running, so long
+// handlers don't have their message redelivered to another worker mid-flight.
+func (c *SQSConsumer) extendVisibility(ctx context.Context, msg *sqs.Message, done <-chan struct{}) {
+	interval := time.Duration(c.visibilityTimeout*8/10) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sqs.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(c.queueURL),
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: aws.Int64(c.visibilityTimeout),
+			})
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const (
+	sqsMaxBatchSize  = 10
+	sqsMaxBatchBytes = 256 * 1024
+)
+
+// MessageAttribute is a typed SQS message attribute. Exactly one of
+// StringValue, NumberValue, or BinaryValue should be set.
+type MessageAttribute struct {
+	StringValue string
+	NumberValue string
+	BinaryValue []byte
+}
+
+func (a MessageAttribute) toSQS() *sqs.MessageAttributeValue {
+	switch {
+	case a.BinaryValue != nil:
+		return &sqs.MessageAttributeValue{DataType: aws.String("Binary"), BinaryValue: a.BinaryValue}
+	case a.NumberValue != "":
+		return &sqs.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(a.NumberValue)}
+	default:
+		return &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(a.StringValue)}
+	}
+}
+
+// Message is a single message queued through SQSProducer.
+type Message struct {
+	Body       string
+	Attributes map[string]MessageAttribute
+
+	// MessageGroupID and MessageDeduplicationID are only used for FIFO
+	// queues.
+	MessageGroupID         string
+	MessageDeduplicationID string
+
+	// DelaySeconds delays this message's visibility. Ignored for FIFO
+	// messages (those with a MessageGroupID), since FIFO queues don't
+	// support per-message delays.
+	DelaySeconds int64
+}
+
+// DeadLetterSink receives messages that failed to send after the
+// SQSProducer's configured number of retries.
+type DeadLetterSink interface {
+	Send(ctx context.Context, msg Message, cause error) error
+}
+
+// QueueDeadLetterSink routes failed messages to another SQS queue.
+type QueueDeadLetterSink struct {
+	Producer *SQSProducer
+}
+
+func (s QueueDeadLetterSink) Send(ctx context.Context, msg Message, cause error) error {
+	if msg.Attributes == nil {
+		msg.Attributes = map[string]MessageAttribute{}
+	}
+	msg.Attributes["dlq-reason"] = MessageAttribute{StringValue: cause.Error()}
+	if err := s.Producer.Enqueue(ctx, msg); err != nil {
+		return err
+	}
+	return s.Producer.Flush(ctx)
+}
+
+// S3DeadLetterSink writes failed messages, together with the cause of
+// failure, as JSON objects under a bucket prefix, keyed by the message's
+// MessageDeduplicationID or, when that is empty, by a timestamp.
+type S3DeadLetterSink struct {
+	S3     S3Interface
+	Bucket string
+	Prefix string
+}
+
+func (s S3DeadLetterSink) Send(ctx context.Context, msg Message, cause error) error {
+	body, err := json.Marshal(struct {
+		Message Message `json:"message"`
+		Cause   string  `json:"cause"`
+	}{Message: msg, Cause: cause.Error()})
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s%s.json", s.Prefix, msg.MessageDeduplicationID)
+	if msg.MessageDeduplicationID == "" {
+		key = fmt.Sprintf("%s%d.json", s.Prefix, time.Now().UnixNano())
+	}
+
+	return s.upload(ctx, key, body)
+}
+
+func (s S3DeadLetterSink) upload(ctx context.Context, key string, body []byte) error {
+	tmp, err := os.CreateTemp("", "dlq-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(body); err != nil {
+		return err
+	}
+
+	return s.S3.UploadFileToS3(tmp.Name(), s.Bucket, key, "application/json")
+}
+
+// CallbackDeadLetterSink hands failed messages to a user-supplied function,
+// e.g. to log them or write them somewhere other than SQS or S3.
+type CallbackDeadLetterSink func(ctx context.Context, msg Message, cause error) error
+
+func (f CallbackDeadLetterSink) Send(ctx context.Context, msg Message, cause error) error {
+	return f(ctx, msg, cause)
+}
+
+// ProducerOption configures an SQSProducer.
+type ProducerOption func(*SQSProducer)
+
+// WithMaxRetries bounds how many times a failed message is retried before
+// being routed to the dead-letter sink. Defaults to 3.
+func WithMaxRetries(n int) ProducerOption {
+	return func(p *SQSProducer) { p.maxRetries = n }
+}
+
+// WithDeadLetterSink configures where messages go once retries are
+// exhausted. Without one, exhausted messages are dropped and their error is
+// returned from Flush.
+func WithDeadLetterSink(sink DeadLetterSink) ProducerOption {
+	return func(p *SQSProducer) { p.dlq = sink }
+}
+
+// SQSProducer batches messages and flushes them with SendMessageBatch,
+// retrying partial batch failures with exponential backoff before routing
+// exhausted messages to a DeadLetterSink.
+type SQSProducer struct {
+	sqs      *sqs.SQS
+	queueURL string
+
+	maxRetries int
+	dlq        DeadLetterSink
+
+	mu      sync.Mutex
+	pending []Message
+}
+
+// NewSQSProducer returns a producer that sends to queueURL using client.
+func NewSQSProducer(client *sqs.SQS, queueURL string, opts ...ProducerOption) *SQSProducer {
+	p := &SQSProducer{
+		sqs:        client,
+		queueURL:   queueURL,
+		maxRetries: 3,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Enqueue buffers msg and flushes automatically once sqsMaxBatchSize
+// messages have accumulated.
+func (p *SQSProducer) Enqueue(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	p.pending = append(p.pending, msg)
+	shouldFlush := len(p.pending) >= sqsMaxBatchSize
+	p.mu.Unlock()
+
+	if shouldFlush {
+		return p.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush sends every buffered message in batches of up to sqsMaxBatchSize.
+func (p *SQSProducer) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+
+	for len(batch) > 0 {
+		n := nextBatchSize(batch)
+		if err := p.sendBatch(ctx, batch[:n]); err != nil {
+			return err
+		}
+		batch = batch[n:]
+	}
+	return nil
+}
+
+// nextBatchSize returns how many leading messages of batch fit within
+// SendMessageBatch's limits of 10 messages and 256KB total payload.
+func nextBatchSize(batch []Message) int {
+	var totalBytes int
+	for i, msg := range batch {
+		if i >= sqsMaxBatchSize {
+			return i
+		}
+		totalBytes += messageSize(msg)
+		if totalBytes > sqsMaxBatchBytes && i > 0 {
+			return i
+		}
+	}
+	return len(batch)
+}
+
+// messageSize estimates msg's contribution to SendMessageBatch's 256KB
+// total payload limit, which counts message attributes as well as the body.
+func messageSize(msg Message) int {
+	size := len(msg.Body)
+	for name, attr := range msg.Attributes {
+		size += len(name) + len(attr.StringValue) + len(attr.NumberValue) + len(attr.BinaryValue)
+	}
+	return size
+}
+
+// Close flushes any remaining buffered messages.
+func (p *SQSProducer) Close(ctx context.Context) error {
+	return p.Flush(ctx)
+}
+
+func (p *SQSProducer) sendBatch(ctx context.Context, msgs []Message) error {
+	toSend := msgs
+
+	for attempt := 0; len(toSend) > 0; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+
+		entries := make([]*sqs.SendMessageBatchRequestEntry, len(toSend))
+		for i, msg := range toSend {
+			entries[i] = toBatchEntry(fmt.Sprintf("%d", i), msg)
+		}
+
+		result, err := p.sqs.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(p.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(result.Failed) == 0 {
+			return nil
+		}
+
+		if attempt >= p.maxRetries {
+			return p.deadLetter(ctx, toSend, result.Failed)
+		}
+
+		toSend = failedMessages(toSend, result.Failed)
+	}
+
+	return nil
+}
+
+func (p *SQSProducer) deadLetter(ctx context.Context, sent []Message, failed []*sqs.BatchResultErrorEntry) error {
+	if p.dlq == nil {
+		return fmt.Errorf("sqs: %d messages failed after retries and no DeadLetterSink is configured", len(failed))
+	}
+
+	var firstErr error
+	for _, f := range failed {
+		idx, err := entryIndex(f.Id)
+		if err != nil || idx >= len(sent) {
+			continue
+		}
+		cause := fmt.Errorf("sqs: send failed (%s): %s", aws.StringValue(f.Code), aws.StringValue(f.Message))
+		if err := p.dlq.Send(ctx, sent[idx], cause); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func toBatchEntry(id string, msg Message) *sqs.SendMessageBatchRequestEntry {
+	entry := &sqs.SendMessageBatchRequestEntry{
+		Id:          aws.String(id),
+		MessageBody: aws.String(msg.Body),
+	}
+
+	if len(msg.Attributes) > 0 {
+		attrs := make(map[string]*sqs.MessageAttributeValue, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			attrs[k] = v.toSQS()
+		}
+		entry.MessageAttributes = attrs
+	}
+
+	if msg.MessageGroupID != "" {
+		entry.MessageGroupId = aws.String(msg.MessageGroupID)
+	}
+	if msg.MessageDeduplicationID != "" {
+		entry.MessageDeduplicationId = aws.String(msg.MessageDeduplicationID)
+	}
+	if msg.DelaySeconds > 0 && msg.MessageGroupID == "" {
+		entry.DelaySeconds = aws.Int64(msg.DelaySeconds)
+	}
+
+	return entry
+}
+
+// failedMessages maps SendMessageBatch's Failed entries back to the
+// Messages that produced them, using the index-based Id assigned in
+// toBatchEntry.
+func failedMessages(sent []Message, failed []*sqs.BatchResultErrorEntry) []Message {
+	out := make([]Message, 0, len(failed))
+	for _, f := range failed {
+		idx, err := entryIndex(f.Id)
+		if err == nil && idx < len(sent) {
+			out = append(out, sent[idx])
+		}
+	}
+	return out
+}
+
+func entryIndex(id *string) (int, error) {
+	var idx int
+	_, err := fmt.Sscanf(aws.StringValue(id), "%d", &idx)
+	return idx, err
+}